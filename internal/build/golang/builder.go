@@ -0,0 +1,144 @@
+// Package golang implements a builder for Go fuzz tests, i.e. fuzz
+// targets defined as native `func FuzzXxx(f *testing.F)` functions in a
+// Go module.
+package golang
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/internal/build"
+)
+
+// BuilderOptions contains all options which can be used to configure a
+// Builder instance.
+type BuilderOptions struct {
+	ProjectDir string
+	TempDir    string
+
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+func (opts *BuilderOptions) validate() error {
+	_, err := os.Stat(opts.ProjectDir)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// Builder builds Go fuzz tests via the native `go test` fuzzing support
+// introduced in Go 1.18.
+type Builder struct {
+	*BuilderOptions
+}
+
+// NewBuilder creates a new Builder instance.
+func NewBuilder(opts *BuilderOptions) (*Builder, error) {
+	err := opts.validate()
+	if err != nil {
+		return nil, err
+	}
+	return &Builder{opts}, nil
+}
+
+// fuzzTestLocation identifies the package a `FuzzXxx(*testing.F)`
+// function lives in.
+type fuzzTestLocation struct {
+	Name       string
+	ImportPath string
+	Dir        string
+}
+
+// FindFuzzTest discovers a `FuzzXxx(*testing.F)` function matching
+// fuzzTest, which may either be the name of the fuzz function or a
+// "<package>.<FuzzXxx>" qualified name. It searches every package in the
+// module because `go test -c` (used by Build) rejects a multi-package
+// pattern, so the fuzz test's own package must be resolved individually.
+func (b *Builder) FindFuzzTest(fuzzTest string) (*fuzzTestLocation, error) {
+	cmd := exec.Command("go", "list", "-f", "{{.ImportPath}} {{.Dir}}", "./...")
+	cmd.Dir = b.ProjectDir
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(string(out)))
+	for scanner.Scan() {
+		fields := strings.SplitN(strings.TrimSpace(scanner.Text()), " ", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		importPath, dir := fields[0], fields[1]
+
+		listCmd := exec.Command("go", "test", "-list", "^Fuzz", importPath)
+		listCmd.Dir = b.ProjectDir
+		listOut, err := listCmd.Output()
+		if err != nil {
+			// The package may not build or may have no tests; it's
+			// simply not a candidate.
+			continue
+		}
+		listScanner := bufio.NewScanner(strings.NewReader(string(listOut)))
+		for listScanner.Scan() {
+			name := strings.TrimSpace(listScanner.Text())
+			if name == "" || strings.HasPrefix(name, "ok ") {
+				continue
+			}
+			if name == fuzzTest || strings.HasSuffix(fuzzTest, "."+name) {
+				return &fuzzTestLocation{Name: name, ImportPath: importPath, Dir: dir}, nil
+			}
+		}
+	}
+	return nil, errors.Errorf("no fuzz test %q found in %s", fuzzTest, b.ProjectDir)
+}
+
+// Build builds an instrumented test binary for the fuzz test with the
+// given name, using `go test -c -fuzz` under the hood. It returns the
+// build result, including the path to the generated corpus directory
+// which `go test -fuzz` maintains for the fuzz test.
+func (b *Builder) Build(fuzzTest string) (*build.Result, error) {
+	loc, err := b.FindFuzzTest(fuzzTest)
+	if err != nil {
+		return nil, err
+	}
+
+	executable := filepath.Join(b.TempDir, loc.Name+".test")
+	args := []string{
+		"test",
+		"-c",
+		"-fuzz=^" + loc.Name + "$",
+		"-o", executable,
+		loc.ImportPath,
+	}
+	cmd := exec.Command("go", args...)
+	cmd.Dir = b.ProjectDir
+	cmd.Stdout = b.Stdout
+	cmd.Stderr = b.Stderr
+	err = cmd.Run()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// `go test -fuzz` keeps the corpus it generates in
+	// <pkg-dir>/testdata/fuzz/<FuzzXxx>, mirroring the layout used by
+	// `go test`'s built-in fuzzing cache.
+	generatedCorpus := filepath.Join(loc.Dir, "testdata", "fuzz", loc.Name)
+
+	return &build.Result{
+		Name:            loc.Name,
+		Executable:      executable,
+		BuildDir:        loc.Dir,
+		ProjectDir:      b.ProjectDir,
+		Engine:          "go",
+		GeneratedCorpus: generatedCorpus,
+		SeedCorpus:      generatedCorpus,
+	}, nil
+}
@@ -0,0 +1,97 @@
+package cache
+
+import (
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/storage"
+)
+
+// New creates the "cifuzz cache" command, which manages the persistent,
+// content-addressed fuzzing cache that "cifuzz run" incrementally
+// populates.
+func New() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the fuzzing cache",
+	}
+
+	cmd.AddCommand(newListCmd(), newPruneCmd(), newCleanCmd())
+
+	return cmd
+}
+
+func openCache() (*storage.FuzzCache, error) {
+	root, err := storage.DefaultCacheRoot()
+	if err != nil {
+		return nil, err
+	}
+	return storage.NewFuzzCache(root, afero.NewOsFs()), nil
+}
+
+func newListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list <fuzz test>",
+		Short: "List cached corpus and crasher entries for a fuzz test",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			fuzzCache, err := openCache()
+			if err != nil {
+				return err
+			}
+			manifest, err := fuzzCache.List(args[0])
+			if err != nil {
+				return err
+			}
+			log.Infof("%d corpus entries, %d crashers", len(manifest.Corpus), len(manifest.Crashers))
+			for hash, entry := range manifest.Corpus {
+				log.Printf("corpus   %s  engine=%s", hash, entry.Engine)
+			}
+			for hash, entry := range manifest.Crashers {
+				log.Printf("crasher  %s  engine=%s", hash, entry.Engine)
+			}
+			return nil
+		},
+	}
+}
+
+func newPruneCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "prune <fuzz test>",
+		Short: "Remove cache entries which are no longer tracked in the manifest",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			fuzzCache, err := openCache()
+			if err != nil {
+				return err
+			}
+			removed, err := fuzzCache.Prune(args[0])
+			if err != nil {
+				return err
+			}
+			log.Successf("Removed %d orphaned cache entries", removed)
+			return nil
+		},
+	}
+}
+
+func newCleanCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "clean <fuzz test>",
+		Short: "Remove the entire fuzzing cache for a fuzz test",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			fuzzCache, err := openCache()
+			if err != nil {
+				return err
+			}
+			err = fuzzCache.Clean(args[0])
+			if err != nil {
+				return err
+			}
+			log.Successf("Cleaned fuzzing cache for %s", args[0])
+			return nil
+		},
+	}
+}
@@ -0,0 +1,354 @@
+package coverage
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"code-intelligence.com/cifuzz/internal/build"
+	"code-intelligence.com/cifuzz/internal/build/bazel"
+	"code-intelligence.com/cifuzz/internal/build/cmake"
+	"code-intelligence.com/cifuzz/internal/build/other"
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/internal/completion"
+	"code-intelligence.com/cifuzz/internal/config"
+	"code-intelligence.com/cifuzz/pkg/dependencies"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/runfiles"
+	"code-intelligence.com/cifuzz/pkg/storage"
+	"code-intelligence.com/cifuzz/util/fileutil"
+)
+
+// Supported values for the "--format" flag.
+const (
+	FormatHTML = "html"
+	FormatLCOV = "lcov"
+	FormatJSON = "json"
+)
+
+type coverageOptions struct {
+	BuildSystem    string   `mapstructure:"build-system"`
+	BuildCommand   string   `mapstructure:"build-command"`
+	NumBuildJobs   uint     `mapstructure:"build-jobs"`
+	SeedCorpusDirs []string `mapstructure:"seed-corpus-dirs"`
+	OutputFormat   string   `mapstructure:"format"`
+	OutputPath     string   `mapstructure:"output"`
+
+	ProjectDir string
+	fuzzTest   string
+}
+
+func (opts *coverageOptions) validate() error {
+	var err error
+
+	opts.SeedCorpusDirs, err = cmdutils.ValidateSeedCorpusDirs(opts.SeedCorpusDirs)
+	if err != nil {
+		log.Error(err, err.Error())
+		return cmdutils.ErrSilent
+	}
+
+	switch opts.OutputFormat {
+	case FormatHTML, FormatLCOV, FormatJSON:
+	default:
+		msg := fmt.Sprintf("invalid argument %q for \"--format\" flag: must be one of %s, %s, %s",
+			opts.OutputFormat, FormatHTML, FormatLCOV, FormatJSON)
+		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+	}
+
+	if opts.BuildSystem == "" {
+		opts.BuildSystem, err = config.DetermineBuildSystem(opts.ProjectDir)
+		if err != nil {
+			return err
+		}
+	} else {
+		err = config.ValidateBuildSystem(opts.BuildSystem)
+		if err != nil {
+			return err
+		}
+	}
+
+	if opts.BuildSystem == config.BuildSystemOther && opts.BuildCommand == "" {
+		msg := "Flag \"build-command\" must be set when using build system type \"other\""
+		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+	}
+
+	return nil
+}
+
+type coverageCmd struct {
+	*cobra.Command
+	opts *coverageOptions
+
+	tempDir string
+}
+
+// New creates the "cifuzz coverage" command, which reruns a fuzz test's
+// corpus to produce an LLVM source-based coverage report.
+func New() *cobra.Command {
+	opts := &coverageOptions{}
+	var bindFlags func()
+
+	cmd := &cobra.Command{
+		Use:   "coverage [flags] <fuzz test>",
+		Short: "Generate a coverage report for a fuzz test",
+		Long: `This command builds the given fuzz test with source-based code
+coverage instrumentation, reruns its corpus and generates a coverage
+report in the format given by the '--format' flag.`,
+		ValidArgsFunction: completion.ValidFuzzTests,
+		Args:              cobra.ExactArgs(1),
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			bindFlags()
+
+			err := config.FindAndParseProjectConfig(opts)
+			if err != nil {
+				log.Errorf(err, "Failed to parse cifuzz.yaml: %v", err.Error())
+				return cmdutils.WrapSilentError(err)
+			}
+
+			opts.fuzzTest = args[0]
+			return opts.validate()
+		},
+		RunE: func(c *cobra.Command, args []string) error {
+			cmd := coverageCmd{Command: c, opts: opts}
+			return cmd.run()
+		},
+	}
+
+	bindFlags = cmdutils.AddFlags(cmd,
+		cmdutils.AddBuildCommandFlag,
+		cmdutils.AddBuildJobsFlag,
+		cmdutils.AddFormatFlag,
+		cmdutils.AddOutputFlag,
+		cmdutils.AddProjectDirFlag,
+		cmdutils.AddSeedCorpusFlag,
+	)
+
+	return cmd
+}
+
+func (c *coverageCmd) run() error {
+	depsOk, err := dependencies.Check([]dependencies.Key{
+		dependencies.CLANG,
+		dependencies.LLVM_PROFDATA,
+		dependencies.LLVM_COV,
+	}, dependencies.Default, runfiles.Finder)
+	if err != nil {
+		return err
+	}
+	if !depsOk {
+		return dependencies.Error()
+	}
+
+	c.tempDir, err = os.MkdirTemp("", "cifuzz-coverage-")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer fileutil.Cleanup(c.tempDir)
+
+	buildResult, err := c.buildFuzzTest()
+	if err != nil {
+		return err
+	}
+
+	profileDir := filepath.Join(c.tempDir, "profiles")
+	err = os.MkdirAll(profileDir, 0755)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	corpusDirs := append([]string{buildResult.GeneratedCorpus, buildResult.SeedCorpus}, c.opts.SeedCorpusDirs...)
+	numRuns, err := c.runCorpus(buildResult.Executable, corpusDirs, profileDir)
+	if err != nil {
+		return err
+	}
+	log.Infof("Collected coverage from %d corpus entries", numRuns)
+
+	profdata, err := c.mergeProfiles(profileDir)
+	if err != nil {
+		return err
+	}
+
+	return c.generateReport(buildResult.Executable, profdata)
+}
+
+func (c *coverageCmd) buildFuzzTest() (*build.Result, error) {
+	// LLVM source-based coverage instrumentation is added as a dedicated
+	// sanitizer, the same way ASan/UBSan are configured.
+	sanitizers := []string{"coverage"}
+
+	if c.opts.BuildSystem == config.BuildSystemBazel {
+		builder, err := bazel.NewBuilder(&bazel.BuilderOptions{
+			ProjectDir: c.opts.ProjectDir,
+			Engine:     "libfuzzer",
+			NumJobs:    c.opts.NumBuildJobs,
+			Stdout:     c.OutOrStdout(),
+			Stderr:     c.ErrOrStderr(),
+			TempDir:    c.tempDir,
+			Verbose:    viper.GetBool("verbose"),
+		})
+		if err != nil {
+			return nil, err
+		}
+		buildResults, err := builder.Build([]string{c.opts.fuzzTest})
+		if err != nil {
+			return nil, err
+		}
+		return buildResults[c.opts.fuzzTest], nil
+	} else if c.opts.BuildSystem == config.BuildSystemCMake {
+		builder, err := cmake.NewBuilder(&cmake.BuilderOptions{
+			ProjectDir: c.opts.ProjectDir,
+			Engine:     "libfuzzer",
+			Sanitizers: sanitizers,
+			Parallel: cmake.ParallelOptions{
+				Enabled: viper.IsSet("build-jobs"),
+				NumJobs: c.opts.NumBuildJobs,
+			},
+			Stdout: c.OutOrStdout(),
+			Stderr: c.ErrOrStderr(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		err = builder.Configure()
+		if err != nil {
+			return nil, err
+		}
+		buildResults, err := builder.Build([]string{c.opts.fuzzTest})
+		if err != nil {
+			return nil, err
+		}
+		return buildResults[c.opts.fuzzTest], nil
+	} else if c.opts.BuildSystem == config.BuildSystemOther {
+		builder, err := other.NewBuilder(&other.BuilderOptions{
+			ProjectDir:   c.opts.ProjectDir,
+			BuildCommand: c.opts.BuildCommand,
+			Engine:       "libfuzzer",
+			Sanitizers:   sanitizers,
+			Stdout:       c.OutOrStdout(),
+			Stderr:       c.ErrOrStderr(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		return builder.Build(c.opts.fuzzTest)
+	}
+
+	return nil, errors.Errorf("Unsupported build system \"%s\"", c.opts.BuildSystem)
+}
+
+// collectSeeds returns the paths of all seed files found in corpusDirs,
+// skipping directories that don't exist.
+func collectSeeds(fs *afero.Afero, corpusDirs []string) ([]string, error) {
+	var seeds []string
+	for _, dir := range corpusDirs {
+		exists, err := fs.Exists(dir)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if !exists {
+			continue
+		}
+
+		entries, err := fs.ReadDir(dir)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			seeds = append(seeds, filepath.Join(dir, entry.Name()))
+		}
+	}
+	return seeds, nil
+}
+
+// runCorpus executes the coverage-instrumented fuzz test binary once per
+// seed in corpusDirs, writing a separate raw profile for each run into
+// profileDir.
+func (c *coverageCmd) runCorpus(executable string, corpusDirs []string, profileDir string) (int, error) {
+	seeds, err := collectSeeds(storage.NewOsFileSystem(), corpusDirs)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, seed := range seeds {
+		profile := filepath.Join(profileDir, fmt.Sprintf("%d.profraw", i))
+
+		cmd := exec.Command(executable, seed)
+		cmd.Env = append(os.Environ(), "LLVM_PROFILE_FILE="+profile)
+		// The fuzz target may legitimately exit non-zero on a seed
+		// that reproduces a known crash; we only care about the
+		// coverage it produced, so errors here aren't fatal.
+		_ = cmd.Run()
+	}
+	return len(seeds), nil
+}
+
+func (c *coverageCmd) mergeProfiles(profileDir string) (string, error) {
+	profraws, err := filepath.Glob(filepath.Join(profileDir, "*.profraw"))
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	if len(profraws) == 0 {
+		return "", errors.New("no coverage profiles were collected, is the corpus empty?")
+	}
+
+	merged := filepath.Join(c.tempDir, "merged.profdata")
+	args := append([]string{"merge", "-sparse", "-o", merged}, profraws...)
+	cmd := exec.Command("llvm-profdata", args...)
+	cmd.Stderr = c.ErrOrStderr()
+	err = cmd.Run()
+	if err != nil {
+		return "", cmdutils.WrapExecError(errors.WithStack(err), cmd)
+	}
+	return merged, nil
+}
+
+func (c *coverageCmd) generateReport(executable, profdata string) error {
+	outputPath := c.opts.OutputPath
+	if outputPath == "" {
+		outputPath = "coverage." + c.opts.OutputFormat
+	}
+
+	var subcommand string
+	var format string
+	switch c.opts.OutputFormat {
+	case FormatHTML:
+		subcommand, format = "show", "html"
+	case FormatLCOV:
+		subcommand, format = "export", "lcov"
+	case FormatJSON:
+		subcommand, format = "export", "text"
+	}
+
+	args := []string{
+		subcommand,
+		"-instr-profile=" + profdata,
+		"-format=" + format,
+		executable,
+	}
+	cmd := exec.Command("llvm-cov", args...)
+	cmd.Stderr = c.ErrOrStderr()
+
+	out, err := os.Create(outputPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer out.Close()
+	cmd.Stdout = out
+
+	err = cmd.Run()
+	if err != nil {
+		return cmdutils.WrapExecError(errors.WithStack(err), cmd)
+	}
+
+	log.Successf("Coverage report written to %s", fileutil.PrettifyPath(outputPath))
+	return nil
+}
@@ -0,0 +1,32 @@
+package coverage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"code-intelligence.com/cifuzz/pkg/storage"
+)
+
+func TestCollectSeeds(t *testing.T) {
+	fs := storage.NewMemFileSystem()
+
+	err := fs.MkdirAll("/seed-corpus", 0755)
+	assert.NoError(t, err)
+	err = fs.WriteFile("/seed-corpus/seed1", []byte("a"), 0644)
+	assert.NoError(t, err)
+	err = fs.WriteFile("/seed-corpus/seed2", []byte("b"), 0644)
+	assert.NoError(t, err)
+
+	seeds, err := collectSeeds(fs, []string{"/seed-corpus"})
+	assert.NoError(t, err)
+	assert.Len(t, seeds, 2)
+}
+
+func TestCollectSeeds_MissingDir(t *testing.T) {
+	fs := storage.NewMemFileSystem()
+
+	seeds, err := collectSeeds(fs, []string{"/does-not-exist"})
+	assert.NoError(t, err)
+	assert.Empty(t, seeds)
+}
@@ -0,0 +1,278 @@
+package run
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/internal/build"
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/util/fileutil"
+)
+
+// maxMinimizeIterations bounds how many times we ask the engine to
+// shrink the input further. Each iteration's candidate must reproduce
+// the exact same crash as the previous one, so this just protects us
+// against engines that keep "succeeding" without making progress.
+const maxMinimizeIterations = 16
+
+var (
+	bugTypeRegexp  = regexp.MustCompile(`(?:AddressSanitizer|UndefinedBehaviorSanitizer|MemorySanitizer|ThreadSanitizer): (\S+)`)
+	topFrameRegexp = regexp.MustCompile(`(?m)^\s*#0\s+0x[0-9a-f]+\s+in\s+(\S+)`)
+)
+
+// crashSignature identifies the bug a crashing input triggers by its
+// sanitizer bug class and the top stack frame. Two inputs are considered
+// to reproduce "the same crash" iff both match.
+type crashSignature struct {
+	BugType  string
+	TopFrame string
+}
+
+func (s crashSignature) empty() bool {
+	return s.BugType == "" && s.TopFrame == ""
+}
+
+func (s crashSignature) equal(other crashSignature) bool {
+	return s.BugType == other.BugType && s.TopFrame == other.TopFrame
+}
+
+// parseCrashSignature extracts a crashSignature from a fuzz target's
+// sanitizer output.
+func parseCrashSignature(output []byte) crashSignature {
+	var sig crashSignature
+	if m := bugTypeRegexp.FindSubmatch(output); m != nil {
+		sig.BugType = string(m[1])
+	}
+	if m := topFrameRegexp.FindSubmatch(output); m != nil {
+		sig.TopFrame = string(m[1])
+	}
+	return sig
+}
+
+// minimizeFunc asks the fuzzing engine to produce a smaller input than
+// input which still crashes, writing it to output. It returns false if
+// the engine couldn't find anything smaller.
+type minimizeFunc func(ctx context.Context, input, output string) (bool, error)
+
+// checkFunc runs the target against a candidate input and returns the
+// crash signature it triggers (the zero value if it doesn't crash).
+type checkFunc func(ctx context.Context, input string) (crashSignature, error)
+
+// reduceCrash repeatedly asks minimize for a smaller reproducer and,
+// using check, keeps only the candidates whose crash signature still
+// matches baseline. It returns the path to the smallest accepted
+// reproducer (which may be input itself if no candidate was accepted)
+// and the number of accepted iterations.
+func reduceCrash(ctx context.Context, tempDir, input string, baseline crashSignature, minimize minimizeFunc, check checkFunc) (string, int, error) {
+	best := input
+	accepted := 0
+
+	for i := 0; i < maxMinimizeIterations; i++ {
+		candidate := filepath.Join(tempDir, fmt.Sprintf("candidate-%d", i))
+		shrunk, err := minimize(ctx, best, candidate)
+		if err != nil {
+			return best, accepted, err
+		}
+		if !shrunk {
+			break
+		}
+
+		sig, err := check(ctx, candidate)
+		if err != nil {
+			return best, accepted, err
+		}
+		if !sig.equal(baseline) {
+			log.Debugf("Rejecting minimized candidate %s: crash signature changed (%+v != %+v)", candidate, sig, baseline)
+			break
+		}
+
+		best = candidate
+		accepted++
+	}
+
+	return best, accepted, nil
+}
+
+// minimizeCrash shrinks the input at c.opts.MinimizeCrash while
+// preserving the sanitizer report it triggers, writing the result next
+// to the original input with a ".min" suffix.
+func (c *runCmd) minimizeCrash(buildResult *build.Result) error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.effectiveMinimizeTimeout())
+	defer cancel()
+
+	check := c.checkCrash(buildResult.Executable)
+
+	baseline, err := check(ctx, c.opts.MinimizeCrash)
+	if err != nil {
+		return err
+	}
+	if baseline.empty() {
+		return errors.Errorf("input %q does not reproduce a crash", c.opts.MinimizeCrash)
+	}
+	log.Infof("Original crash: %s in %s", baseline.BugType, baseline.TopFrame)
+
+	minimize, err := c.engineMinimizer(buildResult.Executable)
+	if err != nil {
+		return err
+	}
+
+	best, accepted, err := reduceCrash(ctx, c.tempDir, c.opts.MinimizeCrash, baseline, minimize, check)
+	if err != nil {
+		return err
+	}
+
+	outPath := c.opts.MinimizeCrash + ".min"
+	err = fileutil.CopyFile(best, outPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	origInfo, err := os.Stat(c.opts.MinimizeCrash)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	minInfo, err := os.Stat(outPath)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if accepted == 0 {
+		log.Infof("Could not shrink %s any further", fileutil.PrettifyPath(c.opts.MinimizeCrash))
+	} else {
+		reduction := 100 * (1 - float64(minInfo.Size())/float64(origInfo.Size()))
+		log.Successf("Minimized crash from %d to %d bytes (%.1f%% smaller), written to %s",
+			origInfo.Size(), minInfo.Size(), reduction, fileutil.PrettifyPath(outPath))
+	}
+
+	return nil
+}
+
+func (c *runCmd) effectiveMinimizeTimeout() time.Duration {
+	if c.opts.MinimizeTimeout != 0 {
+		return c.opts.MinimizeTimeout
+	}
+	return 10 * time.Minute
+}
+
+// checkCrash returns a checkFunc that replays input against executable
+// and parses the resulting sanitizer report.
+func (c *runCmd) checkCrash(executable string) checkFunc {
+	return func(ctx context.Context, input string) (crashSignature, error) {
+		cmd := exec.CommandContext(ctx, executable, input)
+		cmd.Env = append(os.Environ(), "NO_CIFUZZ=1")
+		output, _ := cmd.CombinedOutput()
+		return parseCrashSignature(output), nil
+	}
+}
+
+// engineMinimizer returns the minimizeFunc for the configured engine.
+func (c *runCmd) engineMinimizer(executable string) (minimizeFunc, error) {
+	switch c.opts.Engine {
+	case EngineLibFuzzer, "":
+		return func(ctx context.Context, input, output string) (bool, error) {
+			args := append([]string{
+				"-minimize_crash=1",
+				"-runs=10000",
+				"-exact_artifact_path=" + output,
+			}, c.opts.EngineArgs...)
+			args = append(args, input)
+			cmd := exec.CommandContext(ctx, executable, args...)
+			cmd.Env = append(os.Environ(), "NO_CIFUZZ=1")
+			_ = cmd.Run()
+			return smallerReproducer(input, output)
+		}, nil
+	case EngineAFLPlusPlus:
+		return func(ctx context.Context, input, output string) (bool, error) {
+			cmd := exec.CommandContext(ctx, "afl-tmin", "-i", input, "-o", output, "--", executable)
+			err := cmd.Run()
+			if err != nil {
+				return false, cmdutils.WrapExecError(errors.WithStack(err), cmd)
+			}
+			return smallerReproducer(input, output)
+		}, nil
+	case EngineHonggfuzz:
+		return func(ctx context.Context, input, output string) (bool, error) {
+			// honggfuzz writes the minimized file into --crashdir under
+			// its own name, not at output, so use a scratch crashdir and
+			// pick up whatever it wrote there afterwards.
+			crashDir, err := os.MkdirTemp(filepath.Dir(output), "honggfuzz-minimize-")
+			if err != nil {
+				return false, errors.WithStack(err)
+			}
+			defer os.RemoveAll(crashDir)
+
+			cmd := exec.CommandContext(ctx, "honggfuzz", "--minimize", "--input", input, "--crashdir", crashDir, "--", executable)
+			err = cmd.Run()
+			if err != nil {
+				return false, cmdutils.WrapExecError(errors.WithStack(err), cmd)
+			}
+
+			minimized, err := newestFile(crashDir)
+			if err != nil {
+				return false, err
+			}
+			if minimized == "" {
+				// honggfuzz didn't write a minimized reproducer.
+				return false, nil
+			}
+			err = fileutil.CopyFile(minimized, output)
+			if err != nil {
+				return false, errors.WithStack(err)
+			}
+			return smallerReproducer(input, output)
+		}, nil
+	default:
+		return nil, errors.Errorf("Unsupported engine %q", c.opts.Engine)
+	}
+}
+
+// newestFile returns the path of the most recently modified regular
+// file in dir, or "" if dir contains none.
+func newestFile(dir string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	var newest string
+	var newestModTime time.Time
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+		if info.ModTime().After(newestModTime) {
+			newestModTime = info.ModTime()
+			newest = filepath.Join(dir, entry.Name())
+		}
+	}
+	return newest, nil
+}
+
+// smallerReproducer reports whether the minimizer wrote a strictly
+// smaller file to output than input.
+func smallerReproducer(input, output string) (bool, error) {
+	outInfo, err := os.Stat(output)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, errors.WithStack(err)
+	}
+	inInfo, err := os.Stat(input)
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+	return outInfo.Size() < inInfo.Size(), nil
+}
@@ -0,0 +1,88 @@
+package run
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeFile is a small test helper to create a file of the given size,
+// filled with zero bytes, in dir.
+func writeFile(t *testing.T, dir, name string, size int) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	err := os.WriteFile(path, make([]byte, size), 0644)
+	require.NoError(t, err)
+	return path
+}
+
+func TestReduceCrash_AcceptsOnlyMatchingSignatures(t *testing.T) {
+	tempDir := t.TempDir()
+	input := writeFile(t, tempDir, "orig", 100)
+
+	baseline := crashSignature{BugType: "heap-buffer-overflow", TopFrame: "vulnerable_func"}
+	sizes := []int{80, 60, 40}
+	call := 0
+
+	minimize := func(ctx context.Context, in, out string) (bool, error) {
+		if call >= len(sizes) {
+			return false, nil
+		}
+		size := sizes[call]
+		call++
+		return true, os.WriteFile(out, make([]byte, size), 0644)
+	}
+
+	// The third candidate (call index 2, 40 bytes) reproduces a
+	// different bug and must be rejected, leaving the 60-byte one as
+	// the final accepted result.
+	check := func(ctx context.Context, path string) (crashSignature, error) {
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		if info.Size() == 40 {
+			return crashSignature{BugType: "use-after-free", TopFrame: "other_func"}, nil
+		}
+		return baseline, nil
+	}
+
+	best, accepted, err := reduceCrash(context.Background(), tempDir, input, baseline, minimize, check)
+	require.NoError(t, err)
+	assert.Equal(t, 2, accepted)
+
+	info, err := os.Stat(best)
+	require.NoError(t, err)
+	assert.EqualValues(t, 60, info.Size())
+}
+
+func TestReduceCrash_StopsWhenNoSmallerCandidate(t *testing.T) {
+	tempDir := t.TempDir()
+	input := writeFile(t, tempDir, "orig", 100)
+
+	baseline := crashSignature{BugType: "heap-buffer-overflow", TopFrame: "vulnerable_func"}
+	minimize := func(ctx context.Context, in, out string) (bool, error) {
+		return false, nil
+	}
+	check := func(ctx context.Context, path string) (crashSignature, error) {
+		return baseline, nil
+	}
+
+	best, accepted, err := reduceCrash(context.Background(), tempDir, input, baseline, minimize, check)
+	require.NoError(t, err)
+	assert.Equal(t, 0, accepted)
+	assert.Equal(t, input, best)
+}
+
+func TestParseCrashSignature(t *testing.T) {
+	output := []byte(`==1234==ERROR: AddressSanitizer: heap-buffer-overflow on address 0x...
+READ of size 4 at ...
+    #0 0x55d5a1 in vulnerable_func fuzz_target.c:42
+    #1 0x55d5b2 in LLVMFuzzerTestOneInput fuzz_target.c:10
+`)
+	sig := parseCrashSignature(output)
+	assert.Equal(t, "heap-buffer-overflow", sig.BugType)
+	assert.Equal(t, "vulnerable_func", sig.TopFrame)
+}
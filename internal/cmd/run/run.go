@@ -14,6 +14,7 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/pterm/pterm"
+	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"golang.org/x/sync/errgroup"
@@ -21,6 +22,7 @@ import (
 	"code-intelligence.com/cifuzz/internal/build"
 	"code-intelligence.com/cifuzz/internal/build/bazel"
 	"code-intelligence.com/cifuzz/internal/build/cmake"
+	"code-intelligence.com/cifuzz/internal/build/golang"
 	"code-intelligence.com/cifuzz/internal/build/other"
 	"code-intelligence.com/cifuzz/internal/cmd/run/report_handler"
 	"code-intelligence.com/cifuzz/internal/cmdutils"
@@ -29,21 +31,48 @@ import (
 	"code-intelligence.com/cifuzz/pkg/dependencies"
 	"code-intelligence.com/cifuzz/pkg/log"
 	"code-intelligence.com/cifuzz/pkg/runfiles"
+	"code-intelligence.com/cifuzz/pkg/runner/afl"
+	"code-intelligence.com/cifuzz/pkg/runner/gofuzz"
+	"code-intelligence.com/cifuzz/pkg/runner/honggfuzz"
 	"code-intelligence.com/cifuzz/pkg/runner/libfuzzer"
+	"code-intelligence.com/cifuzz/pkg/storage"
 	"code-intelligence.com/cifuzz/util/fileutil"
 )
 
+// Supported values for the "--engine" flag.
+const (
+	EngineLibFuzzer   = "libfuzzer"
+	EngineAFLPlusPlus = "aflplusplus"
+	EngineHonggfuzz   = "honggfuzz"
+)
+
 type runOptions struct {
-	BuildSystem    string        `mapstructure:"build-system"`
-	BuildCommand   string        `mapstructure:"build-command"`
-	NumBuildJobs   uint          `mapstructure:"build-jobs"`
-	Dictionary     string        `mapstructure:"dict"`
-	EngineArgs     []string      `mapstructure:"engine-args"`
-	RecoverUBSan   bool          `mapstructure:"recover-ubsan"`
-	SeedCorpusDirs []string      `mapstructure:"seed-corpus-dirs"`
-	Timeout        time.Duration `mapstructure:"timeout"`
-	UseSandbox     bool          `mapstructure:"use-sandbox"`
-	PrintJSON      bool          `mapstructure:"print-json"`
+	BuildSystem     string        `mapstructure:"build-system"`
+	BuildCommand    string        `mapstructure:"build-command"`
+	NumBuildJobs    uint          `mapstructure:"build-jobs"`
+	Dictionary      string        `mapstructure:"dict"`
+	Engine          string        `mapstructure:"engine"`
+	EngineArgs      []string      `mapstructure:"engine-args"`
+	MinimizeCrash   string        `mapstructure:"minimize-crash"`
+	MinimizeTimeout time.Duration `mapstructure:"minimize-timeout"`
+	// PerInputTimeout bounds how long the engine may spend on a single
+	// input (libFuzzer's `-timeout`, AFL++'s `-t`). Ignored by the Go
+	// engine, which has no equivalent concept.
+	PerInputTimeout time.Duration `mapstructure:"per-input-timeout"`
+	RecoverUBSan    bool          `mapstructure:"recover-ubsan"`
+	// Runs bounds the number of inputs the engine executes (libFuzzer's
+	// and the Go engine's `-runs`/`-test.fuzztime=Nx`). Ignored by AFL++
+	// and honggfuzz, which have no equivalent concept.
+	Runs           int      `mapstructure:"runs"`
+	SeedCorpusDirs []string `mapstructure:"seed-corpus-dirs"`
+	// Timeout is a deprecated alias for TotalTime, kept for one release.
+	Timeout time.Duration `mapstructure:"timeout"`
+	// TotalTime bounds the overall duration of the fuzzing run
+	// (libFuzzer's `-max_total_time`, AFL++'s and honggfuzz's `-V`/
+	// `--run_time`, the Go engine's `-test.fuzztime`).
+	TotalTime  time.Duration `mapstructure:"total-time"`
+	UseSandbox bool          `mapstructure:"use-sandbox"`
+	PrintJSON  bool          `mapstructure:"print-json"`
 
 	ProjectDir string
 	fuzzTest   string
@@ -52,12 +81,32 @@ type runOptions struct {
 func (opts *runOptions) validate() error {
 	var err error
 
+	if opts.Engine == "" {
+		opts.Engine = EngineLibFuzzer
+	}
+	switch opts.Engine {
+	case EngineLibFuzzer, EngineAFLPlusPlus, EngineHonggfuzz:
+	default:
+		msg := fmt.Sprintf("invalid argument %q for \"--engine\" flag: must be one of %s, %s, %s",
+			opts.Engine, EngineLibFuzzer, EngineAFLPlusPlus, EngineHonggfuzz)
+		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+	}
+
 	opts.SeedCorpusDirs, err = cmdutils.ValidateSeedCorpusDirs(opts.SeedCorpusDirs)
 	if err != nil {
 		log.Error(err, err.Error())
 		return cmdutils.ErrSilent
 	}
 
+	if opts.MinimizeCrash != "" {
+		_, err := os.Stat(opts.MinimizeCrash)
+		if err != nil {
+			err = errors.WithStack(err)
+			log.Error(err, err.Error())
+			return cmdutils.ErrSilent
+		}
+	}
+
 	if opts.Dictionary != "" {
 		// Check if the dictionary exists and can be accessed
 		_, err := os.Stat(opts.Dictionary)
@@ -86,8 +135,36 @@ func (opts *runOptions) validate() error {
 		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
 	}
 
-	if opts.Timeout != 0 && opts.Timeout < time.Second {
-		msg := fmt.Sprintf("invalid argument %q for \"--timeout\" flag: timeout can't be less than a second", opts.Timeout)
+	// "--timeout" is kept as a deprecated alias for "--total-time" for one
+	// release.
+	if opts.Timeout != 0 {
+		if opts.TotalTime != 0 {
+			msg := "\"--timeout\" and \"--total-time\" are mutually exclusive; \"--timeout\" is deprecated, use \"--total-time\" instead"
+			return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+		}
+		log.Warnf("\"--timeout\" is deprecated, use \"--total-time\" instead")
+		opts.TotalTime = opts.Timeout
+	}
+
+	if opts.TotalTime != 0 && opts.TotalTime < time.Second {
+		msg := fmt.Sprintf("invalid argument %q for \"--total-time\" flag: total time can't be less than a second", opts.TotalTime)
+		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+	}
+
+	if opts.PerInputTimeout != 0 && opts.PerInputTimeout < time.Millisecond {
+		msg := fmt.Sprintf("invalid argument %q for \"--per-input-timeout\" flag: per-input timeout can't be less than a millisecond", opts.PerInputTimeout)
+		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+	}
+
+	if opts.Runs < 0 {
+		msg := fmt.Sprintf("invalid argument %d for \"--runs\" flag: runs can't be negative", opts.Runs)
+		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
+	}
+
+	// Reject explicit "--runs 0" combined with an explicit "--total-time 0":
+	// together they'd never execute the fuzz target at all.
+	if viper.IsSet("runs") && opts.Runs == 0 && viper.IsSet("total-time") && opts.TotalTime == 0 {
+		msg := "\"--runs 0\" combined with \"--total-time 0\" would never execute the fuzz target; set at least one of them to a positive value"
 		return cmdutils.WrapIncorrectUsageError(errors.New(msg))
 	}
 
@@ -158,12 +235,20 @@ depends on the build system configured for the project:
 		cmdutils.AddBuildCommandFlag,
 		cmdutils.AddBuildJobsFlag,
 		cmdutils.AddDictFlag,
+		cmdutils.AddEngineFlag,
 		cmdutils.AddEngineArgFlag,
+		cmdutils.AddMinimizeCrashFlag,
+		cmdutils.AddMinimizeTimeoutFlag,
+		cmdutils.AddPerInputTimeoutFlag,
 		cmdutils.AddPrintJSONFlag,
 		cmdutils.AddProjectDirFlag,
 		cmdutils.AddRecoverUBSanFlag,
+		cmdutils.AddRunsFlag,
 		cmdutils.AddSeedCorpusFlag,
+		// AddTimeoutFlag registers the deprecated "--timeout" alias for
+		// "--total-time", kept for one release.
 		cmdutils.AddTimeoutFlag,
+		cmdutils.AddTotalTimeFlag,
 		cmdutils.AddUseSandboxFlag,
 	)
 
@@ -192,6 +277,10 @@ func (c *runCmd) run() error {
 		return err
 	}
 
+	if c.opts.MinimizeCrash != "" {
+		return c.minimizeCrash(buildResult)
+	}
+
 	// Initialize the report handler. Only do this right before we start
 	// the fuzz test, because this is storing a timestamp which is used
 	// to figure out how long the fuzzing run is running.
@@ -253,7 +342,7 @@ func (c *runCmd) buildFuzzTest() (*build.Result, error) {
 
 		builder, err := bazel.NewBuilder(&bazel.BuilderOptions{
 			ProjectDir: c.opts.ProjectDir,
-			Engine:     "libfuzzer",
+			Engine:     c.opts.Engine,
 			NumJobs:    c.opts.NumBuildJobs,
 			Stdout:     c.OutOrStdout(),
 			Stderr:     c.ErrOrStderr(),
@@ -271,8 +360,7 @@ func (c *runCmd) buildFuzzTest() (*build.Result, error) {
 	} else if c.opts.BuildSystem == config.BuildSystemCMake {
 		builder, err := cmake.NewBuilder(&cmake.BuilderOptions{
 			ProjectDir: c.opts.ProjectDir,
-			// TODO: Do not hardcode this value.
-			Engine:     "libfuzzer",
+			Engine:     c.opts.Engine,
 			Sanitizers: sanitizers,
 			Parallel: cmake.ParallelOptions{
 				Enabled: viper.IsSet("build-jobs"),
@@ -297,11 +385,10 @@ func (c *runCmd) buildFuzzTest() (*build.Result, error) {
 		builder, err := other.NewBuilder(&other.BuilderOptions{
 			ProjectDir:   c.opts.ProjectDir,
 			BuildCommand: c.opts.BuildCommand,
-			// TODO: Do not hardcode this value.
-			Engine:     "libfuzzer",
-			Sanitizers: sanitizers,
-			Stdout:     c.OutOrStdout(),
-			Stderr:     c.ErrOrStderr(),
+			Engine:       c.opts.Engine,
+			Sanitizers:   sanitizers,
+			Stdout:       c.OutOrStdout(),
+			Stderr:       c.ErrOrStderr(),
 		})
 		if err != nil {
 			return nil, err
@@ -311,6 +398,17 @@ func (c *runCmd) buildFuzzTest() (*build.Result, error) {
 			return nil, err
 		}
 		return buildResult, nil
+	} else if c.opts.BuildSystem == config.BuildSystemGo {
+		builder, err := golang.NewBuilder(&golang.BuilderOptions{
+			ProjectDir: c.opts.ProjectDir,
+			TempDir:    c.tempDir,
+			Stdout:     c.OutOrStdout(),
+			Stderr:     c.ErrOrStderr(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		return builder.Build(c.opts.fuzzTest)
 	} else {
 		return nil, errors.Errorf("Unsupported build system \"%s\"", c.opts.BuildSystem)
 	}
@@ -320,6 +418,10 @@ func (c *runCmd) runFuzzTest(buildResult *build.Result) error {
 	log.Infof("Running %s", pterm.Style{pterm.Reset, pterm.FgLightBlue}.Sprintf(c.opts.fuzzTest))
 	log.Debugf("Executable: %s", buildResult.Executable)
 
+	if c.opts.BuildSystem == config.BuildSystemGo {
+		return c.runGoFuzzTest(buildResult)
+	}
+
 	err := os.MkdirAll(buildResult.GeneratedCorpus, 0755)
 	if err != nil {
 		return errors.WithStack(err)
@@ -337,6 +439,31 @@ func (c *runCmd) runFuzzTest(buildResult *build.Result) error {
 		seedCorpusDirs = append(seedCorpusDirs, buildResult.SeedCorpus)
 	}
 
+	fuzzCache, err := c.openFuzzCache()
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(fuzzCache.CrashersDir(c.opts.fuzzTest), 0755)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	err = os.MkdirAll(fuzzCache.CorpusDir(c.opts.fuzzTest), 0755)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	seedCorpusDirs = append(seedCorpusDirs, fuzzCache.CorpusDir(c.opts.fuzzTest))
+
+	if c.opts.Engine != EngineLibFuzzer {
+		return c.runAltEngineFuzzTest(buildResult, seedCorpusDirs, fuzzCache)
+	}
+
+	defer c.ingestCorpus(fuzzCache, buildResult.GeneratedCorpus)
+	// The engine writes crashing inputs directly into
+	// fuzzCache.CrashersDir, so the manifest just needs to be told about
+	// them afterwards to keep "cifuzz cache prune" from treating them as
+	// orphans.
+	defer c.ingestCrashers(fuzzCache)
+
 	// Ensure that symlinks are resolved to be able to add minijail
 	// bindings for the corpus dirs.
 	buildResult.GeneratedCorpus, err = filepath.EvalSymlinks(buildResult.GeneratedCorpus)
@@ -369,8 +496,12 @@ func (c *runCmd) runFuzzTest(buildResult *build.Result) error {
 	}
 
 	runnerOpts := &libfuzzer.RunnerOptions{
-		Dictionary:         c.opts.Dictionary,
-		EngineArgs:         c.opts.EngineArgs,
+		Dictionary: c.opts.Dictionary,
+		// Timeout is intentionally not set here: libFuzzerEngineArgs
+		// already derives "-max_total_time" from --total-time, and the
+		// runner would otherwise also translate Timeout into the same
+		// flag, passing it to libFuzzer twice.
+		EngineArgs:         c.libFuzzerEngineArgs(fuzzCache.CrashersDir(c.opts.fuzzTest)),
 		EnvVars:            []string{"NO_CIFUZZ=1"},
 		FuzzTarget:         buildResult.Executable,
 		GeneratedCorpusDir: buildResult.GeneratedCorpus,
@@ -380,7 +511,6 @@ func (c *runCmd) runFuzzTest(buildResult *build.Result) error {
 		RecoverUBSan:       c.opts.RecoverUBSan,
 		ReportHandler:      c.reportHandler,
 		SeedCorpusDirs:     seedCorpusDirs,
-		Timeout:            c.opts.Timeout,
 		UseMinijail:        c.opts.UseSandbox,
 		Verbose:            viper.GetBool("verbose"),
 	}
@@ -433,6 +563,257 @@ func (c *runCmd) runFuzzTest(buildResult *build.Result) error {
 	return err
 }
 
+// altEngineRunner is implemented by the runners for every engine other
+// than libFuzzer. It mirrors the subset of libfuzzer.Runner's contract
+// that runAltEngineFuzzTest needs.
+type altEngineRunner interface {
+	Run(ctx context.Context) error
+	Cleanup(ctx context.Context)
+}
+
+// runAltEngineFuzzTest runs the fuzz target using the AFL++ or honggfuzz
+// driver instead of libFuzzer. Minijail sandboxing is only implemented
+// for libFuzzer, so these engines always run unsandboxed.
+func (c *runCmd) runAltEngineFuzzTest(buildResult *build.Result, seedCorpusDirs []string, fuzzCache *storage.FuzzCache) error {
+	var runner altEngineRunner
+	switch c.opts.Engine {
+	case EngineAFLPlusPlus:
+		if c.opts.Runs != 0 {
+			log.Warnf("\"--runs\" is ignored by the %s engine", EngineAFLPlusPlus)
+		}
+		aflRunner := afl.NewRunner(&afl.RunnerOptions{
+			FuzzTarget:         buildResult.Executable,
+			GeneratedCorpusDir: buildResult.GeneratedCorpus,
+			SeedCorpusDirs:     seedCorpusDirs,
+			// afl-fuzz has no flag to redirect only its crashes
+			// subdirectory, so the runner copies crash files into
+			// CrashersDir itself once the run ends.
+			CrashersDir:     fuzzCache.CrashersDir(c.opts.fuzzTest),
+			Dictionary:      c.opts.Dictionary,
+			EngineArgs:      c.opts.EngineArgs,
+			EnvVars:         []string{"NO_CIFUZZ=1"},
+			Timeout:         c.opts.TotalTime,
+			PerInputTimeout: c.opts.PerInputTimeout,
+			ReportHandler:   c.reportHandler,
+			Verbose:         viper.GetBool("verbose"),
+		})
+		runner = aflRunner
+		// AFL++ keeps its corpus in its own "queue" subdirectory, not
+		// flatly in GeneratedCorpusDir like libFuzzer and honggfuzz.
+		defer c.ingestCorpus(fuzzCache, aflRunner.QueueDir())
+	case EngineHonggfuzz:
+		if c.opts.Runs != 0 {
+			log.Warnf("\"--runs\" is ignored by the %s engine", EngineHonggfuzz)
+		}
+		runner = honggfuzz.NewRunner(&honggfuzz.RunnerOptions{
+			FuzzTarget:         buildResult.Executable,
+			GeneratedCorpusDir: buildResult.GeneratedCorpus,
+			SeedCorpusDirs:     seedCorpusDirs,
+			// Point honggfuzz at the fuzzing cache's crashers directory
+			// directly, mirroring libFuzzer's "-artifact_prefix".
+			CrashDir:        fuzzCache.CrashersDir(c.opts.fuzzTest),
+			Dictionary:      c.opts.Dictionary,
+			EngineArgs:      c.opts.EngineArgs,
+			EnvVars:         []string{"NO_CIFUZZ=1"},
+			Timeout:         c.opts.TotalTime,
+			PerInputTimeout: c.opts.PerInputTimeout,
+			ReportHandler:   c.reportHandler,
+			Verbose:         viper.GetBool("verbose"),
+		})
+		defer c.ingestCorpus(fuzzCache, buildResult.GeneratedCorpus)
+	default:
+		return errors.Errorf("Unsupported engine %q", c.opts.Engine)
+	}
+	// honggfuzz writes crashing inputs directly into
+	// fuzzCache.CrashersDir and the AFL++ runner copies them there once
+	// the run ends, so the manifest just needs to be told about them
+	// afterwards to keep "cifuzz cache prune" from treating them as
+	// orphans.
+	defer c.ingestCrashers(fuzzCache)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM, syscall.SIGINT, syscall.SIGQUIT)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case s := <-sigs:
+			log.Warnf("Received %s", s.String())
+			runner.Cleanup(ctx)
+		}
+	}()
+
+	err := runner.Run(ctx)
+	var execErr *cmdutils.ExecError
+	if errors.As(err, &execErr) {
+		log.Error(err)
+		return cmdutils.WrapSilentError(err)
+	}
+	return err
+}
+
+// runGoFuzzTest runs a fuzz test built by internal/build/golang using the
+// native `testing.F` runner interface instead of libFuzzer.
+func (c *runCmd) runGoFuzzTest(buildResult *build.Result) error {
+	err := os.MkdirAll(buildResult.GeneratedCorpus, 0755)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	log.Infof("Storing generated corpus in %s", fileutil.PrettifyPath(buildResult.GeneratedCorpus))
+
+	if c.opts.PerInputTimeout != 0 {
+		log.Warnf("\"--per-input-timeout\" is ignored by the %s build system", config.BuildSystemGo)
+	}
+
+	runner := gofuzz.NewRunner(&gofuzz.RunnerOptions{
+		FuzzTarget:         buildResult.Executable,
+		FuzzTestName:       buildResult.Name,
+		GeneratedCorpusDir: buildResult.GeneratedCorpus,
+		SeedCorpusDirs:     c.opts.SeedCorpusDirs,
+		Timeout:            c.opts.TotalTime,
+		Runs:               c.opts.Runs,
+		EnvVars:            []string{"NO_CIFUZZ=1"},
+		ReportHandler:      c.reportHandler,
+		Verbose:            viper.GetBool("verbose"),
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM, syscall.SIGINT, syscall.SIGQUIT)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case s := <-sigs:
+			log.Warnf("Received %s", s.String())
+			runner.Cleanup(ctx)
+		}
+	}()
+
+	err = runner.Run(ctx)
+	var execErr *cmdutils.ExecError
+	if errors.As(err, &execErr) {
+		log.Error(err)
+		return cmdutils.WrapSilentError(err)
+	}
+	return err
+}
+
+// openFuzzCache opens the persistent, content-addressed fuzzing cache
+// used to carry a corpus over between incremental "cifuzz run"
+// invocations of the same fuzz test.
+func (c *runCmd) openFuzzCache() (*storage.FuzzCache, error) {
+	root, err := storage.DefaultCacheRoot()
+	if err != nil {
+		return nil, err
+	}
+	return storage.NewFuzzCache(root, afero.NewOsFs()), nil
+}
+
+// ingestCorpus adds every file in dir to the fuzz test's cached corpus.
+// Failures are logged instead of failing the run, since a stale fuzzing
+// cache isn't fatal.
+func (c *runCmd) ingestCorpus(fuzzCache *storage.FuzzCache, dir string) {
+	added, err := fuzzCache.IngestDir(c.opts.fuzzTest, dir, storage.CacheEntry{
+		Engine:     c.opts.Engine,
+		Sanitizers: []string{"address", "undefined"},
+	})
+	if err != nil {
+		log.Debugf("Failed to update fuzzing cache: %v", err)
+		return
+	}
+	log.Debugf("Added %d new corpus entries to fuzzing cache", added)
+}
+
+// ingestCrashers records every file the engine wrote into the fuzz
+// test's cached crashers directory in the manifest. Failures are logged
+// instead of failing the run, since a stale fuzzing cache isn't fatal.
+func (c *runCmd) ingestCrashers(fuzzCache *storage.FuzzCache) {
+	added, err := fuzzCache.RegisterCrashers(c.opts.fuzzTest, storage.CacheEntry{
+		Engine:     c.opts.Engine,
+		Sanitizers: []string{"address", "undefined"},
+	})
+	if err != nil {
+		log.Debugf("Failed to update fuzzing cache: %v", err)
+		return
+	}
+	log.Debugf("Added %d new crashers to fuzzing cache", added)
+}
+
+// libFuzzerEngineArgs returns the full set of libFuzzer engine arguments:
+// the user-supplied "--engine-arg" values, plus the "-max_total_time",
+// "-timeout", "-runs", and "-artifact_prefix" flags derived from the
+// run's other options, for any of those the user didn't already specify
+// explicitly.
+func (c *runCmd) libFuzzerEngineArgs(crashersDir string) []string {
+	args := append(append([]string{}, c.opts.EngineArgs...), c.libFuzzerTimingArgs()...)
+	if !hasEngineArg(c.opts.EngineArgs, "-artifact_prefix=") {
+		args = append(args, "-artifact_prefix="+crashersDir+string(os.PathSeparator))
+	}
+	return args
+}
+
+// libFuzzerTimingArgs translates --total-time, --per-input-timeout, and
+// --runs into libFuzzer's "-max_total_time", "-timeout", and "-runs"
+// flags. If the user already passed one of these explicitly via
+// "--engine-arg", that explicit value wins and we log a warning instead
+// of also setting our derived flag.
+func (c *runCmd) libFuzzerTimingArgs() []string {
+	var args []string
+
+	if c.opts.TotalTime != 0 {
+		if hasEngineArg(c.opts.EngineArgs, "-max_total_time=") {
+			log.Warnf("Ignoring \"--total-time\" because \"-max_total_time\" was set via \"--engine-arg\"")
+		} else {
+			args = append(args, fmt.Sprintf("-max_total_time=%d", int(c.opts.TotalTime.Seconds())))
+		}
+	}
+
+	if c.opts.PerInputTimeout != 0 {
+		if hasEngineArg(c.opts.EngineArgs, "-timeout=") {
+			log.Warnf("Ignoring \"--per-input-timeout\" because \"-timeout\" was set via \"--engine-arg\"")
+		} else {
+			// libFuzzer's "-timeout" only has whole-second granularity,
+			// so round up rather than truncating a sub-second value (which
+			// "--per-input-timeout" allows) down to 0, i.e. "no timeout".
+			args = append(args, fmt.Sprintf("-timeout=%d", ceilSeconds(c.opts.PerInputTimeout)))
+		}
+	}
+
+	if c.opts.Runs != 0 {
+		if hasEngineArg(c.opts.EngineArgs, "-runs=") {
+			log.Warnf("Ignoring \"--runs\" because \"-runs\" was set via \"--engine-arg\"")
+		} else {
+			args = append(args, fmt.Sprintf("-runs=%d", c.opts.Runs))
+		}
+	}
+
+	return args
+}
+
+// ceilSeconds rounds d up to the next whole second, so that a sub-second
+// duration doesn't truncate down to 0 when passed to a flag with only
+// whole-second granularity.
+func ceilSeconds(d time.Duration) int {
+	secs := d / time.Second
+	if d%time.Second != 0 {
+		secs++
+	}
+	return int(secs)
+}
+
+// hasEngineArg reports whether engineArgs already contains a flag with
+// the given prefix (e.g. "-max_total_time=").
+func hasEngineArg(engineArgs []string, prefix string) bool {
+	for _, arg := range engineArgs {
+		if strings.HasPrefix(arg, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *runCmd) printFinalMetrics(generatedCorpus, seedCorpus string) error {
 	numSeeds, err := countSeeds(append(c.opts.SeedCorpusDirs, generatedCorpus, seedCorpus))
 	if err != nil {
@@ -443,7 +824,21 @@ func (c *runCmd) printFinalMetrics(generatedCorpus, seedCorpus string) error {
 }
 
 func (c *runCmd) checkDependencies() (bool, error) {
-	deps := []dependencies.Key{dependencies.CLANG, dependencies.LLVM_SYMBOLIZER}
+	// Go fuzz tests are built with the Go toolchain's own instrumentation
+	// and don't require clang or llvm-symbolizer.
+	if c.opts.BuildSystem == config.BuildSystemGo {
+		return dependencies.Check([]dependencies.Key{dependencies.GO}, dependencies.Default, runfiles.Finder)
+	}
+
+	var deps []dependencies.Key
+	switch c.opts.Engine {
+	case EngineAFLPlusPlus:
+		deps = append(deps, dependencies.AFL_FUZZ, dependencies.AFL_CLANG_FAST)
+	case EngineHonggfuzz:
+		deps = append(deps, dependencies.HONGGFUZZ, dependencies.HFUZZ_CLANG)
+	default:
+		deps = append(deps, dependencies.CLANG, dependencies.LLVM_SYMBOLIZER)
+	}
 	if c.opts.BuildSystem == config.BuildSystemCMake {
 		deps = append(deps, dependencies.CMAKE)
 	}
@@ -0,0 +1,47 @@
+package run
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHasEngineArg(t *testing.T) {
+	engineArgs := []string{"-foo=1", "-max_total_time=30"}
+	assert.True(t, hasEngineArg(engineArgs, "-max_total_time="))
+	assert.False(t, hasEngineArg(engineArgs, "-timeout="))
+}
+
+func TestLibFuzzerTimingArgs(t *testing.T) {
+	c := &runCmd{opts: &runOptions{
+		TotalTime:       30 * time.Second,
+		PerInputTimeout: 5 * time.Second,
+		Runs:            1000,
+	}}
+	args := c.libFuzzerTimingArgs()
+	assert.Equal(t, []string{"-max_total_time=30", "-timeout=5", "-runs=1000"}, args)
+}
+
+func TestLibFuzzerTimingArgs_ExplicitEngineArgWins(t *testing.T) {
+	c := &runCmd{opts: &runOptions{
+		TotalTime:  30 * time.Second,
+		EngineArgs: []string{"-max_total_time=60"},
+	}}
+	args := c.libFuzzerTimingArgs()
+	assert.Empty(t, args)
+}
+
+func TestLibFuzzerTimingArgs_SubSecondPerInputTimeoutRoundsUp(t *testing.T) {
+	c := &runCmd{opts: &runOptions{
+		PerInputTimeout: 500 * time.Millisecond,
+	}}
+	args := c.libFuzzerTimingArgs()
+	assert.Equal(t, []string{"-timeout=1"}, args)
+}
+
+func TestCeilSeconds(t *testing.T) {
+	assert.Equal(t, 1, ceilSeconds(500*time.Millisecond))
+	assert.Equal(t, 5, ceilSeconds(5*time.Second))
+	assert.Equal(t, 0, ceilSeconds(0))
+}
@@ -0,0 +1,261 @@
+// Package afl implements a runner for fuzz targets built against AFL++
+// (american fuzzy lop plus plus), using its `afl-fuzz` driver.
+package afl
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/internal/cmd/run/report_handler"
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/report"
+	"code-intelligence.com/cifuzz/util/fileutil"
+)
+
+// RunnerOptions contains all options which can be used to configure the
+// Runner.
+type RunnerOptions struct {
+	FuzzTarget string
+
+	// GeneratedCorpusDir is the top-level directory AFL++ manages for
+	// this fuzz target. AFL++ creates the well-known `queue`, `crashes`,
+	// and `hangs` subdirectories underneath it.
+	GeneratedCorpusDir string
+	SeedCorpusDirs     []string
+
+	// CrashersDir is the persistent fuzzing cache's crashers directory.
+	// afl-fuzz itself has no flag to redirect only its crashes
+	// subdirectory elsewhere, so crash files are copied here after the
+	// run instead of being written directly to it.
+	CrashersDir string
+
+	Dictionary string
+	EngineArgs []string
+	EnvVars    []string
+
+	// Timeout bounds the overall run, passed as `-V <seconds>`.
+	Timeout time.Duration
+	// PerInputTimeout bounds how long afl-fuzz may spend on a single
+	// input, passed as `-t <milliseconds>`.
+	PerInputTimeout time.Duration
+
+	ReportHandler *report_handler.ReportHandler
+	Verbose       bool
+}
+
+func (opts *RunnerOptions) validate() error {
+	if opts.FuzzTarget == "" {
+		return errors.New("FuzzTarget must be set")
+	}
+	if opts.GeneratedCorpusDir == "" {
+		return errors.New("GeneratedCorpusDir must be set")
+	}
+	return nil
+}
+
+// QueueDir and CrashesDir are AFL++'s fixed per-run directories
+// underneath the output directory (-o).
+func (opts *RunnerOptions) QueueDir() string {
+	return filepath.Join(opts.GeneratedCorpusDir, "default", "queue")
+}
+func (opts *RunnerOptions) CrashesDir() string {
+	return filepath.Join(opts.GeneratedCorpusDir, "default", "crashes")
+}
+
+// Runner runs a fuzz target via AFL++'s afl-fuzz driver.
+type Runner struct {
+	*RunnerOptions
+	cmd *exec.Cmd
+}
+
+// NewRunner creates a new Runner instance.
+func NewRunner(opts *RunnerOptions) *Runner {
+	return &Runner{RunnerOptions: opts}
+}
+
+// Run starts afl-fuzz against the fuzz target and streams any findings it
+// reports through the report handler until ctx is cancelled.
+func (r *Runner) Run(ctx context.Context) error {
+	err := r.validate()
+	if err != nil {
+		return err
+	}
+
+	// afl-fuzz only accepts a single "-i" directory and refuses to start
+	// when "-i" and "-o" are the same directory, so every seed corpus
+	// dir is merged into one scratch input dir rather than reusing
+	// GeneratedCorpusDir or just the first SeedCorpusDirs entry. If none
+	// of them contain a seed, fall back to a single zero-byte one. The
+	// scratch dir is wiped first so seeds removed from SeedCorpusDirs
+	// since the last run don't linger here.
+	inputDir := filepath.Join(r.GeneratedCorpusDir, "..", "afl-seed")
+	err = os.RemoveAll(inputDir)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	err = os.MkdirAll(inputDir, 0755)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	seeded := false
+	for i, dir := range r.SeedCorpusDirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return errors.WithStack(err)
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			seeded = true
+			err = fileutil.CopyFile(
+				filepath.Join(dir, entry.Name()),
+				filepath.Join(inputDir, fmt.Sprintf("%d-%s", i, entry.Name())),
+			)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if !seeded {
+		err = os.WriteFile(filepath.Join(inputDir, "seed"), nil, 0644)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	args := []string{
+		"-i", inputDir,
+		"-o", r.GeneratedCorpusDir,
+	}
+	if r.Timeout != 0 {
+		args = append(args, "-V", formatSeconds(r.Timeout))
+	}
+	if r.PerInputTimeout != 0 {
+		args = append(args, "-t", strconv.Itoa(int(r.PerInputTimeout.Milliseconds())))
+	}
+	if r.Dictionary != "" {
+		args = append(args, "-x", r.Dictionary)
+	}
+	args = append(args, r.EngineArgs...)
+	args = append(args, "--", r.FuzzTarget)
+
+	cmd := exec.CommandContext(ctx, "afl-fuzz", args...)
+	cmd.Env = append(os.Environ(), append([]string{"AFL_USE_ASAN=1"}, r.EnvVars...)...)
+	cmd.Stdout = os.Stdout
+
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	err = cmd.Start()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	r.cmd = cmd
+
+	err = r.logStderr(stderr)
+	if err != nil {
+		return err
+	}
+
+	err = cmd.Wait()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return cmdutils.WrapExecError(errors.WithStack(err), cmd)
+		}
+		return errors.WithStack(err)
+	}
+
+	return r.reportCrashes()
+}
+
+// logStderr reads afl-fuzz's stderr line by line and logs it when
+// verbose. AFL++ doesn't emit a structured per-crash format on stderr;
+// crashes are instead picked up from CrashesDir() once the run ends, in
+// reportCrashes.
+func (r *Runner) logStderr(stderr io.Reader) error {
+	scanner := bufio.NewScanner(stderr)
+	for scanner.Scan() {
+		if r.Verbose {
+			log.Debugf("%s", scanner.Text())
+		}
+	}
+	return errors.WithStack(scanner.Err())
+}
+
+// reportCrashes translates every crashing input afl-fuzz saved to
+// CrashesDir() into the module's common crash struct and forwards it to
+// the report handler, copying it into the persistent fuzzing cache's
+// crashers directory along the way.
+func (r *Runner) reportCrashes() error {
+	entries, err := os.ReadDir(r.CrashesDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return errors.WithStack(err)
+	}
+
+	for _, entry := range entries {
+		// AFL++ writes a "README.txt" into a freshly created crashes
+		// dir alongside its "id:..."-named crash files; skip it.
+		if !strings.HasPrefix(entry.Name(), "id:") {
+			continue
+		}
+
+		crashFile := filepath.Join(r.CrashesDir(), entry.Name())
+		if r.CrashersDir != "" {
+			err = fileutil.CopyFile(crashFile, filepath.Join(r.CrashersDir, entry.Name()))
+			if err != nil {
+				return err
+			}
+		}
+
+		if r.ReportHandler == nil {
+			continue
+		}
+		err = r.ReportHandler.Handle(&report.Report{
+			Status: report.RunStatusError,
+			Finding: &report.Finding{
+				Name:      entry.Name(),
+				InputFile: crashFile,
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Cleanup terminates afl-fuzz if it is still running.
+func (r *Runner) Cleanup(ctx context.Context) {
+	if r.cmd == nil || r.cmd.Process == nil {
+		return
+	}
+	err := r.cmd.Process.Kill()
+	if err != nil {
+		log.Debugf("Failed to kill afl-fuzz process: %v", err)
+	}
+}
+
+func formatSeconds(d time.Duration) string {
+	return strconv.Itoa(int(d.Seconds()))
+}
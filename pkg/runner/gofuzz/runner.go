@@ -0,0 +1,233 @@
+// Package gofuzz implements a runner for Go fuzz test binaries built via
+// `go test -c -fuzz`, using the `-test.fuzz` runner interface that ships
+// with Go's native fuzzing support.
+package gofuzz
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/internal/cmd/run/report_handler"
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/report"
+	"code-intelligence.com/cifuzz/util/fileutil"
+)
+
+// RunnerOptions contains all options which can be used to configure the
+// Runner.
+type RunnerOptions struct {
+	// FuzzTarget is the path to the instrumented test binary built by
+	// internal/build/golang.
+	FuzzTarget string
+	// FuzzTestName is the name of the FuzzXxx function to run, as
+	// passed to `-test.fuzz`.
+	FuzzTestName string
+
+	// GeneratedCorpusDir is the fuzz test's `testdata/fuzz/<FuzzXxx>`
+	// directory. The package directory the test binary is run from is
+	// derived from it (see packageDir). Go's native fuzzing only reads
+	// seeds from `f.Add` calls and from that directory, never from
+	// positional arguments.
+	GeneratedCorpusDir string
+	// SeedCorpusDirs are copied into GeneratedCorpusDir before the run,
+	// since Go's native fuzzing has no flag to read seeds from
+	// elsewhere.
+	SeedCorpusDirs []string
+
+	// Timeout bounds the overall fuzzing time, passed as
+	// `-test.fuzztime=<duration>`. Ignored if Runs is set.
+	Timeout time.Duration
+	// Runs bounds the total number of fuzz target executions instead of
+	// the overall time, passed as `-test.fuzztime=<Runs>x`.
+	Runs int
+
+	EnvVars       []string
+	ReportHandler *report_handler.ReportHandler
+
+	Verbose bool
+}
+
+func (opts *RunnerOptions) validate() error {
+	if opts.FuzzTarget == "" {
+		return errors.New("FuzzTarget must be set")
+	}
+	if opts.FuzzTestName == "" {
+		return errors.New("FuzzTestName must be set")
+	}
+	return nil
+}
+
+// packageDir returns the fuzz test's package directory, three levels up
+// from GeneratedCorpusDir ("<pkg>/testdata/fuzz/<FuzzXxx>").
+func (opts *RunnerOptions) packageDir() string {
+	return filepath.Dir(filepath.Dir(filepath.Dir(opts.GeneratedCorpusDir)))
+}
+
+// Runner runs a Go fuzz test binary.
+type Runner struct {
+	*RunnerOptions
+	cmd *exec.Cmd
+}
+
+// NewRunner creates a new Runner instance.
+func NewRunner(opts *RunnerOptions) *Runner {
+	return &Runner{RunnerOptions: opts}
+}
+
+// Run starts the fuzz test binary and streams its output through the
+// configured report handler until it exits or ctx is cancelled.
+func (r *Runner) Run(ctx context.Context) error {
+	err := r.validate()
+	if err != nil {
+		return err
+	}
+
+	err = r.copySeeds()
+	if err != nil {
+		return err
+	}
+
+	args := []string{
+		"-test.fuzz=^" + r.FuzzTestName + "$",
+		"-test.fuzzcachedir=" + r.GeneratedCorpusDir,
+	}
+	if r.Runs != 0 {
+		args = append(args, fmt.Sprintf("-test.fuzztime=%dx", r.Runs))
+	} else if r.Timeout != 0 {
+		args = append(args, "-test.fuzztime="+r.Timeout.String())
+	}
+
+	cmd := exec.CommandContext(ctx, r.FuzzTarget, args...)
+	cmd.Dir = r.packageDir()
+	cmd.Env = append(os.Environ(), r.EnvVars...)
+
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	cmd.Stdout = os.Stdout
+
+	err = cmd.Start()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	r.cmd = cmd
+
+	err = r.handleReports(stderr)
+	if err != nil {
+		return err
+	}
+
+	err = cmd.Wait()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return cmdutils.WrapExecError(errors.WithStack(err), cmd)
+		}
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// copySeeds copies every file in SeedCorpusDirs into GeneratedCorpusDir.
+// Go's native fuzzing only reads seeds from `f.Add` calls and from
+// testdata/fuzz/<FuzzTestName>, never from positional arguments, so
+// --seed-corpus-dirs would otherwise silently have no effect.
+func (r *Runner) copySeeds() error {
+	if len(r.SeedCorpusDirs) == 0 {
+		return nil
+	}
+	err := os.MkdirAll(r.GeneratedCorpusDir, 0755)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for _, dir := range r.SeedCorpusDirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return errors.WithStack(err)
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			err = fileutil.CopyFile(
+				filepath.Join(dir, entry.Name()),
+				filepath.Join(r.GeneratedCorpusDir, entry.Name()),
+			)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// failingInputMarker is the prefix of the line `go test -fuzz` prints
+// once it has written a crashing input to disk, e.g.
+// `Failing input written to testdata/fuzz/FuzzFoo/1234567890abcdef`.
+const failingInputMarker = "Failing input written to "
+
+// handleReports reads the fuzz test's stderr line by line and forwards
+// crashes to the report handler via its common Handle(*report.Report)
+// API, the same way findings from libFuzzer-based runners are reported.
+func (r *Runner) handleReports(stderr io.Reader) error {
+	scanner := bufio.NewScanner(stderr)
+	inFailure := false
+	for scanner.Scan() {
+		line := scanner.Text()
+		if r.Verbose {
+			log.Debugf("%s", line)
+		}
+
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "--- FAIL:"):
+			inFailure = true
+		case inFailure && strings.Contains(line, failingInputMarker):
+			inFailure = false
+			if r.ReportHandler == nil {
+				continue
+			}
+			idx := strings.Index(line, failingInputMarker)
+			inputFile := strings.TrimSpace(line[idx+len(failingInputMarker):])
+			if !filepath.IsAbs(inputFile) {
+				inputFile = filepath.Join(r.packageDir(), inputFile)
+			}
+			err := r.ReportHandler.Handle(&report.Report{
+				Status: report.RunStatusError,
+				Finding: &report.Finding{
+					Name:      r.FuzzTestName,
+					InputFile: inputFile,
+				},
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return errors.WithStack(scanner.Err())
+}
+
+// Cleanup terminates the fuzz test binary if it is still running.
+func (r *Runner) Cleanup(ctx context.Context) {
+	if r.cmd == nil || r.cmd.Process == nil {
+		return
+	}
+	err := r.cmd.Process.Kill()
+	if err != nil {
+		log.Debugf("Failed to kill gofuzz process: %v", err)
+	}
+}
@@ -0,0 +1,254 @@
+// Package honggfuzz implements a runner for fuzz targets built against
+// honggfuzz, using its `honggfuzz` driver binary.
+package honggfuzz
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"code-intelligence.com/cifuzz/internal/cmd/run/report_handler"
+	"code-intelligence.com/cifuzz/internal/cmdutils"
+	"code-intelligence.com/cifuzz/pkg/log"
+	"code-intelligence.com/cifuzz/pkg/report"
+	"code-intelligence.com/cifuzz/util/fileutil"
+)
+
+// RunnerOptions contains all options which can be used to configure the
+// Runner.
+type RunnerOptions struct {
+	FuzzTarget string
+
+	// GeneratedCorpusDir is honggfuzz's flat input/output corpus
+	// directory (`--input`).
+	GeneratedCorpusDir string
+	// SeedCorpusDirs are copied into GeneratedCorpusDir before the run,
+	// since honggfuzz only reads seeds from the single directory passed
+	// via "--input".
+	SeedCorpusDirs []string
+
+	// CrashDir is where honggfuzz writes crashing inputs (`--crashdir`).
+	// Pass the persistent fuzzing cache's crashers directory here so
+	// crashes land directly in the cache, mirroring how libFuzzer is
+	// pointed at it via `-artifact_prefix`.
+	CrashDir string
+
+	Dictionary string
+	EngineArgs []string
+	EnvVars    []string
+
+	// Timeout bounds the overall run, passed as `--run_time <seconds>`.
+	Timeout time.Duration
+	// PerInputTimeout bounds how long honggfuzz may spend on a single
+	// input, passed as `--timeout <seconds>`.
+	PerInputTimeout time.Duration
+
+	ReportHandler *report_handler.ReportHandler
+	Verbose       bool
+}
+
+func (opts *RunnerOptions) validate() error {
+	if opts.FuzzTarget == "" {
+		return errors.New("FuzzTarget must be set")
+	}
+	if opts.GeneratedCorpusDir == "" {
+		return errors.New("GeneratedCorpusDir must be set")
+	}
+	return nil
+}
+
+// crashDir returns CrashDir, falling back to GeneratedCorpusDir if unset.
+func (opts *RunnerOptions) crashDir() string {
+	if opts.CrashDir != "" {
+		return opts.CrashDir
+	}
+	return opts.GeneratedCorpusDir
+}
+
+// Runner runs a fuzz target via the honggfuzz driver.
+type Runner struct {
+	*RunnerOptions
+	cmd *exec.Cmd
+}
+
+// NewRunner creates a new Runner instance.
+func NewRunner(opts *RunnerOptions) *Runner {
+	return &Runner{RunnerOptions: opts}
+}
+
+// Run starts honggfuzz against the fuzz target and streams any findings
+// it reports through the report handler until ctx is cancelled.
+func (r *Runner) Run(ctx context.Context) error {
+	err := r.validate()
+	if err != nil {
+		return err
+	}
+
+	err = r.copySeeds()
+	if err != nil {
+		return err
+	}
+
+	args := []string{
+		"--input", r.GeneratedCorpusDir,
+		"--crashdir", r.crashDir(),
+	}
+	if r.Timeout != 0 {
+		args = append(args, "--run_time", strconv.Itoa(int(r.Timeout.Seconds())))
+	}
+	if r.PerInputTimeout != 0 {
+		args = append(args, "--timeout", strconv.Itoa(ceilSeconds(r.PerInputTimeout)))
+	}
+	if r.Dictionary != "" {
+		args = append(args, "--dict", r.Dictionary)
+	}
+	args = append(args, r.EngineArgs...)
+	args = append(args, "--", r.FuzzTarget)
+
+	cmd := exec.CommandContext(ctx, "honggfuzz", args...)
+	cmd.Env = append(os.Environ(), r.EnvVars...)
+	cmd.Stdout = os.Stdout
+
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	err = cmd.Start()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	r.cmd = cmd
+
+	err = r.handleReports(stderr)
+	if err != nil {
+		return err
+	}
+
+	err = cmd.Wait()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return cmdutils.WrapExecError(errors.WithStack(err), cmd)
+		}
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// copySeeds copies every file in SeedCorpusDirs into GeneratedCorpusDir.
+// honggfuzz only reads seeds from the flat directory passed via
+// "--input", not from any additional paths, so SeedCorpusDirs would
+// otherwise silently have no effect. Entries are prefixed with their
+// source directory's index so same-named seeds from different
+// SeedCorpusDirs don't overwrite each other.
+func (r *Runner) copySeeds() error {
+	if len(r.SeedCorpusDirs) == 0 {
+		return nil
+	}
+	err := os.MkdirAll(r.GeneratedCorpusDir, 0755)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	for i, dir := range r.SeedCorpusDirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return errors.WithStack(err)
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			err = fileutil.CopyFile(
+				filepath.Join(dir, entry.Name()),
+				filepath.Join(r.GeneratedCorpusDir, fmt.Sprintf("%d-%s", i, entry.Name())),
+			)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ceilSeconds rounds d up to the next whole second, so that a sub-second
+// duration doesn't truncate down to 0 when passed to "--timeout", which
+// only has whole-second granularity.
+func ceilSeconds(d time.Duration) int {
+	secs := d / time.Second
+	if d%time.Second != 0 {
+		secs++
+	}
+	return int(secs)
+}
+
+// crashSavedMarker is the prefix of honggfuzz's stderr line reporting
+// the path of a newly saved crashing input, e.g.
+// `Saved as 'crashdir/SIGSEGV.PC.1234.STACK.abcd.fuzz'`.
+const crashSavedMarker = "Saved as '"
+
+// handleReports reads honggfuzz's stderr line by line, logging it when
+// verbose and translating "Saved as '...'" crash notifications into the
+// module's common crash struct, the same way findings from libFuzzer-based
+// runners are reported.
+func (r *Runner) handleReports(stderr io.Reader) error {
+	scanner := bufio.NewScanner(stderr)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if r.Verbose {
+			log.Debugf("%s", line)
+		}
+
+		idx := strings.Index(line, crashSavedMarker)
+		if idx == -1 {
+			continue
+		}
+		rest := line[idx+len(crashSavedMarker):]
+		end := strings.IndexByte(rest, '\'')
+		if end == -1 {
+			continue
+		}
+		crashFile := rest[:end]
+		if !filepath.IsAbs(crashFile) {
+			crashFile = filepath.Join(r.crashDir(), filepath.Base(crashFile))
+		}
+
+		if r.ReportHandler == nil {
+			continue
+		}
+		err := r.ReportHandler.Handle(&report.Report{
+			Status: report.RunStatusError,
+			Finding: &report.Finding{
+				Name:      filepath.Base(crashFile),
+				InputFile: crashFile,
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return errors.WithStack(scanner.Err())
+}
+
+// Cleanup terminates honggfuzz if it is still running.
+func (r *Runner) Cleanup(ctx context.Context) {
+	if r.cmd == nil || r.cmd.Process == nil {
+		return
+	}
+	err := r.cmd.Process.Kill()
+	if err != nil {
+		log.Debugf("Failed to kill honggfuzz process: %v", err)
+	}
+}
@@ -0,0 +1,320 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+)
+
+// manifestFileName is the name of the file in which a fuzz test's cache
+// entry metadata is persisted, relative to its cache directory.
+const manifestFileName = "manifest.json"
+
+// CacheEntry describes a single corpus or crasher file stored in the
+// cache, keyed by its SHA-256 hash in the containing Manifest.
+type CacheEntry struct {
+	Engine     string   `json:"engine"`
+	Sanitizers []string `json:"sanitizers,omitempty"`
+	// Features is the number of libFuzzer coverage features this input
+	// was found to hit, if known.
+	Features int `json:"features,omitempty"`
+}
+
+// Manifest is the metadata persisted alongside a fuzz test's cached
+// corpus and crasher entries.
+type Manifest struct {
+	Corpus   map[string]CacheEntry `json:"corpus"`
+	Crashers map[string]CacheEntry `json:"crashers"`
+}
+
+func newManifest() *Manifest {
+	return &Manifest{
+		Corpus:   map[string]CacheEntry{},
+		Crashers: map[string]CacheEntry{},
+	}
+}
+
+// FuzzCache is a persistent, content-addressed store for fuzzing corpus
+// and crasher files, laid out as:
+//
+//	<root>/<fuzz-test-fqn>/corpus/<sha256>
+//	<root>/<fuzz-test-fqn>/crashers/<sha256>
+//	<root>/<fuzz-test-fqn>/manifest.json
+//
+// Crashers that an engine writes into the crashers directory itself and
+// that are recorded via RegisterCrashers keep the engine's own file
+// names instead of their SHA-256 hash.
+//
+// This mirrors the layout Go's native fuzzing cache uses, so that
+// fuzzing runs can be resumed incrementally instead of starting from an
+// empty corpus every time.
+type FuzzCache struct {
+	root string
+	fs   afero.Fs
+}
+
+// NewFuzzCache creates a FuzzCache rooted at root, using fs to access
+// the filesystem.
+func NewFuzzCache(root string, fs afero.Fs) *FuzzCache {
+	return &FuzzCache{root: root, fs: fs}
+}
+
+func (c *FuzzCache) testDir(fuzzTest string) string {
+	return filepath.Join(c.root, fuzzTest)
+}
+
+// CorpusDir returns the directory holding fuzzTest's cached corpus
+// entries.
+func (c *FuzzCache) CorpusDir(fuzzTest string) string {
+	return filepath.Join(c.testDir(fuzzTest), "corpus")
+}
+
+// CrashersDir returns the directory holding fuzzTest's cached crashers.
+func (c *FuzzCache) CrashersDir(fuzzTest string) string {
+	return filepath.Join(c.testDir(fuzzTest), "crashers")
+}
+
+func (c *FuzzCache) manifestPath(fuzzTest string) string {
+	return filepath.Join(c.testDir(fuzzTest), manifestFileName)
+}
+
+func (c *FuzzCache) loadManifest(fuzzTest string) (*Manifest, error) {
+	path := c.manifestPath(fuzzTest)
+	exists, err := afero.Exists(c.fs, path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if !exists {
+		return newManifest(), nil
+	}
+
+	data, err := afero.ReadFile(c.fs, path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	manifest := newManifest()
+	err = json.Unmarshal(data, manifest)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return manifest, nil
+}
+
+func (c *FuzzCache) saveManifest(fuzzTest string, manifest *Manifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	err = c.fs.MkdirAll(c.testDir(fuzzTest), 0755)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(afero.WriteFile(c.fs, c.manifestPath(fuzzTest), data, 0644))
+}
+
+// addEntry stores data under dir, named by its SHA-256 hash, and records
+// it in the manifest's entries map. If an entry with the same hash
+// already exists, the file is not rewritten and the existing metadata is
+// kept. It returns the hash of the entry.
+func (c *FuzzCache) addEntry(dir string, entries map[string]CacheEntry, data []byte, meta CacheEntry) (string, error) {
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	if _, exists := entries[hash]; exists {
+		return hash, nil
+	}
+
+	err := c.fs.MkdirAll(dir, 0755)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	err = afero.WriteFile(c.fs, filepath.Join(dir, hash), data, 0644)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	entries[hash] = meta
+	return hash, nil
+}
+
+// AddCorpusEntry stores data as a corpus entry for fuzzTest, deduplicating
+// by SHA-256. It returns the entry's hash.
+func (c *FuzzCache) AddCorpusEntry(fuzzTest string, data []byte, meta CacheEntry) (string, error) {
+	manifest, err := c.loadManifest(fuzzTest)
+	if err != nil {
+		return "", err
+	}
+	hash, err := c.addEntry(c.CorpusDir(fuzzTest), manifest.Corpus, data, meta)
+	if err != nil {
+		return "", err
+	}
+	return hash, c.saveManifest(fuzzTest, manifest)
+}
+
+// AddCrasher stores data as a crasher for fuzzTest, deduplicating by
+// SHA-256. It returns the entry's hash.
+func (c *FuzzCache) AddCrasher(fuzzTest string, data []byte, meta CacheEntry) (string, error) {
+	manifest, err := c.loadManifest(fuzzTest)
+	if err != nil {
+		return "", err
+	}
+	hash, err := c.addEntry(c.CrashersDir(fuzzTest), manifest.Crashers, data, meta)
+	if err != nil {
+		return "", err
+	}
+	return hash, c.saveManifest(fuzzTest, manifest)
+}
+
+// RegisterCrashers records every file currently in fuzzTest's crashers
+// directory in the manifest, so that Prune doesn't treat them as
+// orphans. Unlike AddCrasher, it doesn't copy or rehash anything: the
+// engines already write crashing inputs directly into CrashersDir, under
+// their own names, so this just catches the manifest up with what's on
+// disk. It returns the number of entries that were newly recorded.
+func (c *FuzzCache) RegisterCrashers(fuzzTest string, meta CacheEntry) (int, error) {
+	dir := c.CrashersDir(fuzzTest)
+	exists, err := afero.DirExists(c.fs, dir)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	if !exists {
+		return 0, nil
+	}
+
+	manifest, err := c.loadManifest(fuzzTest)
+	if err != nil {
+		return 0, err
+	}
+
+	entries, err := afero.ReadDir(c.fs, dir)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	added := 0
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if _, ok := manifest.Crashers[entry.Name()]; ok {
+			continue
+		}
+		manifest.Crashers[entry.Name()] = meta
+		added++
+	}
+
+	return added, c.saveManifest(fuzzTest, manifest)
+}
+
+// IngestDir adds every regular file in dir to fuzzTest's cached corpus,
+// deduplicating by SHA-256. It returns the number of entries that were
+// newly added (i.e. not already present in the cache).
+func (c *FuzzCache) IngestDir(fuzzTest, dir string, meta CacheEntry) (int, error) {
+	exists, err := afero.DirExists(c.fs, dir)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	if !exists {
+		return 0, nil
+	}
+
+	manifest, err := c.loadManifest(fuzzTest)
+	if err != nil {
+		return 0, err
+	}
+
+	entries, err := afero.ReadDir(c.fs, dir)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+
+	added := 0
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		data, err := afero.ReadFile(c.fs, filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return added, errors.WithStack(err)
+		}
+		before := len(manifest.Corpus)
+		_, err = c.addEntry(c.CorpusDir(fuzzTest), manifest.Corpus, data, meta)
+		if err != nil {
+			return added, err
+		}
+		if len(manifest.Corpus) > before {
+			added++
+		}
+	}
+
+	return added, c.saveManifest(fuzzTest, manifest)
+}
+
+// List returns the cached corpus and crasher entries for fuzzTest.
+func (c *FuzzCache) List(fuzzTest string) (*Manifest, error) {
+	return c.loadManifest(fuzzTest)
+}
+
+// Prune removes corpus and crasher files which are no longer referenced
+// by the manifest, e.g. because a previous run was interrupted while
+// writing them. It returns the number of files removed.
+func (c *FuzzCache) Prune(fuzzTest string) (int, error) {
+	manifest, err := c.loadManifest(fuzzTest)
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, dir := range []struct {
+		path    string
+		entries map[string]CacheEntry
+	}{
+		{c.CorpusDir(fuzzTest), manifest.Corpus},
+		{c.CrashersDir(fuzzTest), manifest.Crashers},
+	} {
+		exists, err := afero.DirExists(c.fs, dir.path)
+		if err != nil {
+			return removed, errors.WithStack(err)
+		}
+		if !exists {
+			continue
+		}
+		files, err := afero.ReadDir(c.fs, dir.path)
+		if err != nil {
+			return removed, errors.WithStack(err)
+		}
+		for _, f := range files {
+			if _, ok := dir.entries[f.Name()]; ok {
+				continue
+			}
+			err = c.fs.Remove(filepath.Join(dir.path, f.Name()))
+			if err != nil && !os.IsNotExist(err) {
+				return removed, errors.WithStack(err)
+			}
+			removed++
+		}
+	}
+
+	return removed, nil
+}
+
+// Clean removes the entire cache for fuzzTest.
+func (c *FuzzCache) Clean(fuzzTest string) error {
+	return errors.WithStack(c.fs.RemoveAll(c.testDir(fuzzTest)))
+}
+
+// DefaultCacheRoot returns the directory under which the fuzzing cache is
+// stored by default, similar to how Go's own build and test caches are
+// rooted under the user's cache directory.
+func DefaultCacheRoot() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return filepath.Join(dir, "cifuzz", "fuzzing-cache"), nil
+}
@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFuzzCache_AddCorpusEntry_Dedup(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	cache := NewFuzzCache("/cache", fs)
+
+	hash1, err := cache.AddCorpusEntry("my_fuzz_test", []byte("input"), CacheEntry{Engine: "libfuzzer"})
+	assert.NoError(t, err)
+
+	hash2, err := cache.AddCorpusEntry("my_fuzz_test", []byte("input"), CacheEntry{Engine: "libfuzzer"})
+	assert.NoError(t, err)
+	assert.Equal(t, hash1, hash2)
+
+	manifest, err := cache.List("my_fuzz_test")
+	assert.NoError(t, err)
+	assert.Len(t, manifest.Corpus, 1)
+
+	exists, err := afero.Exists(fs, cache.CorpusDir("my_fuzz_test")+"/"+hash1)
+	assert.NoError(t, err)
+	assert.True(t, exists)
+}
+
+func TestFuzzCache_IngestDir(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	cache := NewFuzzCache("/cache", fs)
+
+	err := fs.MkdirAll("/generated-corpus", 0755)
+	assert.NoError(t, err)
+	err = afero.WriteFile(fs, "/generated-corpus/seed1", []byte("a"), 0644)
+	assert.NoError(t, err)
+	err = afero.WriteFile(fs, "/generated-corpus/seed2", []byte("b"), 0644)
+	assert.NoError(t, err)
+
+	added, err := cache.IngestDir("my_fuzz_test", "/generated-corpus", CacheEntry{Engine: "libfuzzer"})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, added)
+
+	// Ingesting the same directory again should not add any new entries.
+	added, err = cache.IngestDir("my_fuzz_test", "/generated-corpus", CacheEntry{Engine: "libfuzzer"})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, added)
+}
+
+func TestFuzzCache_Prune(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	cache := NewFuzzCache("/cache", fs)
+
+	_, err := cache.AddCorpusEntry("my_fuzz_test", []byte("tracked"), CacheEntry{Engine: "libfuzzer"})
+	assert.NoError(t, err)
+
+	// Simulate an orphaned file left behind by an interrupted run.
+	err = afero.WriteFile(fs, cache.CorpusDir("my_fuzz_test")+"/orphan", []byte("orphan"), 0644)
+	assert.NoError(t, err)
+
+	removed, err := cache.Prune("my_fuzz_test")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, removed)
+}
+
+func TestFuzzCache_RegisterCrashers(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	cache := NewFuzzCache("/cache", fs)
+
+	// Simulate an engine writing a crash file directly into CrashersDir.
+	err := afero.WriteFile(fs, cache.CrashersDir("my_fuzz_test")+"/crash1", []byte("boom"), 0644)
+	assert.NoError(t, err)
+
+	added, err := cache.RegisterCrashers("my_fuzz_test", CacheEntry{Engine: "libfuzzer"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, added)
+
+	// Registering again should not add any new entries.
+	added, err = cache.RegisterCrashers("my_fuzz_test", CacheEntry{Engine: "libfuzzer"})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, added)
+
+	// Prune must no longer treat the registered crasher as an orphan.
+	removed, err := cache.Prune("my_fuzz_test")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, removed)
+
+	exists, err := afero.Exists(fs, cache.CrashersDir("my_fuzz_test")+"/crash1")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+}
+
+func TestFuzzCache_Clean(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	cache := NewFuzzCache("/cache", fs)
+
+	_, err := cache.AddCorpusEntry("my_fuzz_test", []byte("input"), CacheEntry{Engine: "libfuzzer"})
+	assert.NoError(t, err)
+
+	err = cache.Clean("my_fuzz_test")
+	assert.NoError(t, err)
+
+	exists, err := afero.DirExists(fs, cache.testDir("my_fuzz_test"))
+	assert.NoError(t, err)
+	assert.False(t, exists)
+}